@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrUnauthenticated is returned by an Authenticator when a request carries
+// no usable credentials for its auth mode.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Authenticator resolves the User associated with an inbound request. Each
+// auth mode (basic, session-cookie, jwt-bearer) has its own implementation.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*User, error)
+}
+
+// BasicAuthenticator checks the request's HTTP Basic credentials against a
+// Store.
+type BasicAuthenticator struct {
+	Store *Store
+}
+
+func (a BasicAuthenticator) Authenticate(r *http.Request) (*User, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	user, ok := a.Store.Authenticate(username, password)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return user, nil
+}
+
+// SessionAuthenticator checks the request's session cookie against a
+// SessionStore, then looks the resulting username up in a Store.
+type SessionAuthenticator struct {
+	Store    *Store
+	Sessions *SessionStore
+}
+
+func (a SessionAuthenticator) Authenticate(r *http.Request) (*User, error) {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	username, ok := a.Sessions.Validate(cookie.Value)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	user, ok := a.Store.Get(username)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return user, nil
+}
+
+// JWTAuthenticator checks the request's Bearer token against Secret, then
+// looks the resulting username up in a Store.
+type JWTAuthenticator struct {
+	Store  *Store
+	Secret []byte
+}
+
+func (a JWTAuthenticator) Authenticate(r *http.Request) (*User, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, ErrUnauthenticated
+	}
+	username, err := VerifyJWT(a.Secret, strings.TrimPrefix(header, "Bearer "))
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+	user, ok := a.Store.Get(username)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return user, nil
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+type jwtClaims struct {
+	Scopes []Scope `json:"scopes"`
+	jwt.StandardClaims
+}
+
+// IssueJWT creates a signed, HMAC-secured bearer token for user, valid for
+// ttl, for the jwt-bearer auth mode.
+func IssueJWT(secret []byte, user *User, ttl time.Duration) (string, error) {
+	claims := jwtClaims{
+		Scopes: user.Scopes,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   user.Username,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(ttl).Unix(),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+// VerifyJWT validates a bearer token against secret and returns the subject
+// username it was issued for.
+func VerifyJWT(secret []byte, tokenStr string) (string, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &jwtClaims{}, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(*jwtClaims)
+	if !ok || !token.Valid {
+		return "", fmt.Errorf("invalid token")
+	}
+	return claims.Subject, nil
+}
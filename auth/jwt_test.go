@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssueAndVerifyJWT(t *testing.T) {
+	secret := []byte("test-secret")
+	user := &User{Username: "alice", Scopes: []Scope{ScopeRead}}
+
+	token, err := IssueJWT(secret, user, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueJWT: %v", err)
+	}
+
+	subject, err := VerifyJWT(secret, token)
+	if err != nil {
+		t.Fatalf("VerifyJWT: %v", err)
+	}
+	if subject != "alice" {
+		t.Errorf("VerifyJWT: subject = %q, want %q", subject, "alice")
+	}
+}
+
+func TestVerifyJWTRejectsExpired(t *testing.T) {
+	secret := []byte("test-secret")
+	user := &User{Username: "alice", Scopes: []Scope{ScopeRead}}
+
+	token, err := IssueJWT(secret, user, -time.Second)
+	if err != nil {
+		t.Fatalf("IssueJWT: %v", err)
+	}
+
+	if _, err := VerifyJWT(secret, token); err == nil {
+		t.Error("VerifyJWT: expected an already-expired token to be rejected")
+	}
+}
+
+func TestVerifyJWTRejectsWrongSecret(t *testing.T) {
+	user := &User{Username: "alice", Scopes: []Scope{ScopeRead}}
+
+	token, err := IssueJWT([]byte("right-secret"), user, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueJWT: %v", err)
+	}
+
+	if _, err := VerifyJWT([]byte("wrong-secret"), token); err == nil {
+		t.Error("VerifyJWT: expected a token signed with a different secret to be rejected")
+	}
+}
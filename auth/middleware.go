@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+type contextKey int
+
+const userContextKey contextKey = 0
+
+// WithUser returns a context carrying the authenticated user.
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext returns the user previously stored by Require, if any.
+func UserFromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(userContextKey).(*User)
+	return user, ok
+}
+
+// Require wraps a goji context-handler so that it only runs once
+// authenticator has resolved a user with the given scope, storing that user
+// in the request context. An empty scope only requires authentication.
+func Require(authenticator Authenticator, scope Scope, handler func(context.Context, http.ResponseWriter, *http.Request)) func(context.Context, http.ResponseWriter, *http.Request) {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		user, err := authenticator.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="bananapod"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if scope != "" && !user.HasScope(scope) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		handler(WithUser(ctx, user), w, r)
+	}
+}
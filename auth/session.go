@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// SessionCookieName is the cookie session-cookie auth reads and writes.
+const SessionCookieName = "bananapod_session"
+
+type sessionEntry struct {
+	Username string
+	Expires  time.Time
+}
+
+// SessionStore maps opaque tokens to the username that created them, for
+// the session-cookie auth mode.
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]sessionEntry
+	ttl      time.Duration
+}
+
+// NewSessionStore creates an in-memory session store whose tokens expire
+// ttl after creation.
+func NewSessionStore(ttl time.Duration) *SessionStore {
+	return &SessionStore{sessions: make(map[string]sessionEntry), ttl: ttl}
+}
+
+// Create issues a new session token for username.
+func (s *SessionStore) Create(username string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	s.sessions[token] = sessionEntry{Username: username, Expires: time.Now().Add(s.ttl)}
+	s.mu.Unlock()
+	return token, nil
+}
+
+// Validate returns the username a (non-expired) token was issued for.
+func (s *SessionStore) Validate(token string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.sessions[token]
+	if !ok || time.Now().After(entry.Expires) {
+		delete(s.sessions, token)
+		return "", false
+	}
+	return entry.Username, true
+}
+
+// Delete invalidates token, used on logout.
+func (s *SessionStore) Delete(token string) {
+	s.mu.Lock()
+	delete(s.sessions, token)
+	s.mu.Unlock()
+}
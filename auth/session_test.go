@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionStoreCreateAndValidate(t *testing.T) {
+	s := NewSessionStore(time.Hour)
+
+	token, err := s.Create("alice")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if token == "" {
+		t.Fatal("Create: expected a non-empty token")
+	}
+
+	username, ok := s.Validate(token)
+	if !ok || username != "alice" {
+		t.Errorf("Validate = %q, %v; want %q, true", username, ok, "alice")
+	}
+}
+
+func TestSessionStoreExpiry(t *testing.T) {
+	s := NewSessionStore(-time.Second)
+
+	token, err := s.Create("bob")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, ok := s.Validate(token); ok {
+		t.Error("Validate: expected an already-expired token to be rejected")
+	}
+	// the expired entry should have been evicted, not just reported invalid
+	s.mu.Lock()
+	_, present := s.sessions[token]
+	s.mu.Unlock()
+	if present {
+		t.Error("Validate: expected the expired entry to be evicted from the store")
+	}
+	if _, ok := s.Validate(token); ok {
+		t.Error("Validate: expired token should stay rejected on a second check")
+	}
+}
+
+func TestSessionStoreDelete(t *testing.T) {
+	s := NewSessionStore(time.Hour)
+
+	token, err := s.Create("carol")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	s.Delete(token)
+
+	if _, ok := s.Validate(token); ok {
+		t.Error("Validate: expected a deleted token to be rejected")
+	}
+}
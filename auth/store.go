@@ -0,0 +1,175 @@
+// Package auth implements a small users-with-scopes authentication
+// subsystem: a bcrypt-backed user store plus pluggable request
+// authenticators (HTTP Basic, session cookies, JWT bearer tokens) selected
+// at startup via the server's -auth flag.
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Scope is a coarse-grained permission granted to a user.
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"
+	ScopeAdmin Scope = "admin"
+)
+
+// User is a single account in the users store. PasswordHash is always a
+// bcrypt hash; plaintext passwords are never persisted. It is excluded from
+// JSON so API responses (e.g. /me, /admin/users) never echo it back.
+type User struct {
+	Username     string  `json:"username"`
+	PasswordHash string  `json:"-"`
+	Scopes       []Scope `json:"scopes"`
+}
+
+// storedUser is the on-disk representation of a User, including the bcrypt
+// hash that User itself omits from JSON so it can't leak through API
+// responses that happen to encode a *User directly.
+type storedUser struct {
+	Username     string  `json:"username"`
+	PasswordHash string  `json:"passwordHash"`
+	Scopes       []Scope `json:"scopes"`
+}
+
+// HasScope reports whether u was granted scope.
+func (u *User) HasScope(scope Scope) bool {
+	for _, s := range u.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Store is a JSON-file-backed collection of users, guarded by a mutex since
+// it is read and written from concurrent request handlers.
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	users map[string]*User
+}
+
+type storeFile struct {
+	Users []*storedUser `json:"users"`
+}
+
+// NewStore loads the users store at path. If path does not yet exist, it is
+// seeded with a single admin account (adminUser/adminPassword), generating a
+// random password and printing it once if adminPassword is empty, so that no
+// credentials ever need to be compiled in.
+func NewStore(path, adminUser, adminPassword string) (*Store, error) {
+	s := &Store{path: path, users: make(map[string]*User)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		if adminPassword == "" {
+			adminPassword, err = randomPassword()
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("auth: no users store found at %v, generated password for user %q: %v\n", path, adminUser, adminPassword)
+		}
+		if err := s.SetPassword(adminUser, adminPassword, []Scope{ScopeRead, ScopeAdmin}); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored storeFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	for _, u := range stored.Users {
+		s.users[u.Username] = &User{Username: u.Username, PasswordHash: u.PasswordHash, Scopes: u.Scopes}
+	}
+	return s, nil
+}
+
+func randomPassword() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Get returns the user with the given username, if one exists.
+func (s *Store) Get(username string) (*User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[username]
+	return u, ok
+}
+
+// List returns every user in the store.
+func (s *Store) List() []*User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]*User, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, u)
+	}
+	return users
+}
+
+// SetPassword creates or updates username with a freshly bcrypt-hashed
+// password and scopes, persisting the change to disk.
+func (s *Store) SetPassword(username, password string, scopes []Scope) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[username] = &User{Username: username, PasswordHash: string(hash), Scopes: scopes}
+	return s.saveLocked()
+}
+
+// Delete removes username from the store, persisting the change to disk.
+func (s *Store) Delete(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, username)
+	return s.saveLocked()
+}
+
+// Authenticate checks password against username's stored bcrypt hash.
+func (s *Store) Authenticate(username, password string) (*User, bool) {
+	s.mu.Lock()
+	user, ok := s.users[username]
+	s.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, false
+	}
+	return user, true
+}
+
+func (s *Store) saveLocked() error {
+	users := make([]*storedUser, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, &storedUser{Username: u.Username, PasswordHash: u.PasswordHash, Scopes: u.Scopes})
+	}
+	data, err := json.MarshalIndent(storeFile{Users: users}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0600)
+}
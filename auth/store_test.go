@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreSetPasswordAndAuthenticate(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "users.json"), "admin", "adminpass")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	if err := s.SetPassword("alice", "s3cret", []Scope{ScopeRead}); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+
+	user, ok := s.Authenticate("alice", "s3cret")
+	if !ok {
+		t.Fatal("Authenticate: expected alice/s3cret to succeed")
+	}
+	if !user.HasScope(ScopeRead) || user.HasScope(ScopeAdmin) {
+		t.Errorf("Authenticate: scopes = %v, want [read]", user.Scopes)
+	}
+
+	if _, ok := s.Authenticate("alice", "wrong"); ok {
+		t.Error("Authenticate: expected a wrong password to fail")
+	}
+	if _, ok := s.Authenticate("nobody", "s3cret"); ok {
+		t.Error("Authenticate: expected an unknown user to fail")
+	}
+}
+
+func TestStorePersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+
+	s, err := NewStore(path, "admin", "adminpass")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := s.SetPassword("bob", "hunter2", []Scope{ScopeRead, ScopeAdmin}); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+
+	reopened, err := NewStore(path, "admin", "adminpass")
+	if err != nil {
+		t.Fatalf("NewStore (reopen): %v", err)
+	}
+	if _, ok := reopened.Authenticate("bob", "hunter2"); !ok {
+		t.Error("Authenticate after reopen: expected bob's password to have persisted")
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "users.json"), "admin", "adminpass")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := s.SetPassword("alice", "s3cret", []Scope{ScopeRead}); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	if err := s.Delete("alice"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := s.Get("alice"); ok {
+		t.Error("Get: expected alice to be gone after Delete")
+	}
+}
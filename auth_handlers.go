@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mpetri/bananapod-srv/auth"
+	"golang.org/x/net/context"
+)
+
+var (
+	userStore     *auth.Store
+	sessionStore  *auth.SessionStore
+	authenticator auth.Authenticator
+	jwtSecret     []byte
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	Token string `json:"token,omitempty"`
+}
+
+// Login authenticates a username/password pair and, depending on -auth,
+// either starts a session (setting a cookie) or issues a bearer token.
+func Login(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid login request", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := userStore.Authenticate(req.Username, req.Password)
+	if !ok {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	switch *authMode {
+	case "session-cookie":
+		token, err := sessionStore.Create(user.Username)
+		if err != nil {
+			log.Printf("Error creating session for %v: %v", user.Username, err.Error())
+			http.Error(w, "error creating session", http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: auth.SessionCookieName, Value: token, Path: "/", HttpOnly: true})
+		json.NewEncoder(w).Encode(loginResponse{})
+	case "jwt-bearer":
+		token, err := auth.IssueJWT(jwtSecret, user, 24*time.Hour)
+		if err != nil {
+			log.Printf("Error issuing token for %v: %v", user.Username, err.Error())
+			http.Error(w, "error issuing token", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(loginResponse{Token: token})
+	default:
+		http.Error(w, fmt.Sprintf("login is not supported in %v auth mode", *authMode), http.StatusBadRequest)
+	}
+}
+
+// Logout invalidates the caller's session, if the server is running in
+// session-cookie auth mode.
+func Logout(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if *authMode == "session-cookie" {
+		if cookie, err := r.Cookie(auth.SessionCookieName); err == nil {
+			sessionStore.Delete(cookie.Value)
+		}
+		http.SetCookie(w, &http.Cookie{Name: auth.SessionCookieName, Value: "", Path: "/", MaxAge: -1})
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me returns the profile of the currently authenticated user.
+func Me(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	json.NewEncoder(w).Encode(user)
+}
+
+type upsertUserRequest struct {
+	Username string       `json:"username"`
+	Password string       `json:"password"`
+	Scopes   []auth.Scope `json:"scopes"`
+}
+
+// AdminUsers is an admin-only CRUD API over the users store: GET lists
+// users, POST/PUT creates or updates one, DELETE (?username=) removes one.
+func AdminUsers(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(userStore.List())
+	case http.MethodPost, http.MethodPut:
+		var req upsertUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid user payload", http.StatusBadRequest)
+			return
+		}
+		if err := userStore.SetPassword(req.Username, req.Password, req.Scopes); err != nil {
+			log.Printf("Error creating user %v: %v", req.Username, err.Error())
+			http.Error(w, "error creating user", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		username := r.URL.Query().Get("username")
+		if username == "" {
+			http.Error(w, "missing required query parameter: username", http.StatusBadRequest)
+			return
+		}
+		if err := userStore.Delete(username); err != nil {
+			log.Printf("Error deleting user %v: %v", username, err.Error())
+			http.Error(w, "error deleting user", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,208 @@
+// Package cache implements a bounded, disk-backed blob cache keyed by the
+// (source path, mod time) pair of the file a blob was derived from. Unlike a
+// plain in-memory map, entries survive process restarts. "Bounded" means an
+// LRU eviction policy caps total blob size at maxBytes; callers that know a
+// source document is gone should also call Delete so its blob doesn't
+// outlive it on disk.
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a single cached blob plus the validators needed to answer
+// conditional GET requests without re-reading the source file.
+type Entry struct {
+	SHA256  string
+	ModTime time.Time
+	Data    []byte
+}
+
+// FileCache stores blobs on disk under dir, indexed in memory by a hash of
+// their (sourcePath, modTime) key, and evicts least-recently-used entries
+// once the cached bytes exceed maxBytes.
+type FileCache struct {
+	dir      string
+	maxBytes int64
+
+	mu       sync.Mutex
+	entries  map[string]*Entry
+	order    *list.List
+	elems    map[string]*list.Element
+	curBytes int64
+}
+
+// NewFileCache creates (or reopens) a disk-backed cache rooted at dir,
+// loading any blobs already present from a previous run. maxBytes bounds the
+// total size of cached blobs; a non-positive value means unbounded.
+func NewFileCache(dir string, maxBytes int64) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	fc := &FileCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*Entry),
+		order:    list.New(),
+		elems:    make(map[string]*list.Element),
+	}
+	if err := fc.load(); err != nil {
+		return nil, err
+	}
+	fc.mu.Lock()
+	fc.evictLocked()
+	fc.mu.Unlock()
+	return fc, nil
+}
+
+func (fc *FileCache) load() error {
+	files, err := ioutil.ReadDir(fc.dir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".blob") {
+			continue
+		}
+		key := strings.TrimSuffix(fi.Name(), ".blob")
+		data, err := ioutil.ReadFile(filepath.Join(fc.dir, fi.Name()))
+		if err != nil {
+			continue
+		}
+
+		// the blob's own mtime is just when it was written to disk; the
+		// source document's mtime is persisted alongside it so ETag/
+		// Last-Modified validators survive a restart
+		modTime := fi.ModTime()
+		if raw, err := ioutil.ReadFile(filepath.Join(fc.dir, key+".meta")); err == nil {
+			if parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(raw))); err == nil {
+				modTime = parsed
+			}
+		}
+
+		sum := sha256.Sum256(data)
+		fc.entries[key] = &Entry{SHA256: hex.EncodeToString(sum[:]), ModTime: modTime, Data: data}
+		fc.elems[key] = fc.order.PushBack(key)
+		fc.curBytes += int64(len(data))
+	}
+	return nil
+}
+
+func keyFor(sourcePath string, modTime time.Time) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v|%v", sourcePath, modTime.UnixNano())))
+	return hex.EncodeToString(sum[:])
+}
+
+func (fc *FileCache) blobPath(key string) string { return filepath.Join(fc.dir, key+".blob") }
+func (fc *FileCache) metaPath(key string) string { return filepath.Join(fc.dir, key+".meta") }
+
+// Get returns the cached blob for (sourcePath, modTime), if present. A
+// changed modTime misses the cache, which is how stale entries are avoided
+// without an explicit invalidation step. A hit marks the entry as most
+// recently used.
+func (fc *FileCache) Get(sourcePath string, modTime time.Time) (*Entry, bool) {
+	key := keyFor(sourcePath, modTime)
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	entry, ok := fc.entries[key]
+	if ok {
+		if elem, ok := fc.elems[key]; ok {
+			fc.order.MoveToFront(elem)
+		}
+	}
+	return entry, ok
+}
+
+// Put stores data for (sourcePath, modTime).
+func (fc *FileCache) Put(sourcePath string, modTime time.Time, data []byte) (*Entry, error) {
+	return fc.PutReader(sourcePath, modTime, bytes.NewReader(data))
+}
+
+// PutReader reads r to completion into memory, computing its SHA-256 while
+// copying, writes the bytes to the on-disk blob, and returns the resulting Entry so callers
+// can use its digest as an ETag without a second pass over the data. If the
+// new entry pushes the cache over maxBytes, the least-recently-used entries
+// are evicted (both from memory and disk) until it fits again.
+func (fc *FileCache) PutReader(sourcePath string, modTime time.Time, r io.Reader) (*Entry, error) {
+	hasher := sha256.New()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.TeeReader(r, hasher)); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+
+	key := keyFor(sourcePath, modTime)
+	if err := ioutil.WriteFile(fc.blobPath(key), data, 0644); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(fc.metaPath(key), []byte(modTime.UTC().Format(time.RFC3339Nano)), 0644); err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{SHA256: hex.EncodeToString(hasher.Sum(nil)), ModTime: modTime, Data: data}
+	fc.mu.Lock()
+	if old, ok := fc.entries[key]; ok {
+		fc.curBytes -= int64(len(old.Data))
+	} else {
+		fc.elems[key] = fc.order.PushFront(key)
+	}
+	if elem, ok := fc.elems[key]; ok {
+		fc.order.MoveToFront(elem)
+	}
+	fc.entries[key] = entry
+	fc.curBytes += int64(len(data))
+	fc.evictLocked()
+	fc.mu.Unlock()
+	return entry, nil
+}
+
+// Delete removes the cached blob for (sourcePath, modTime), if any, from
+// both memory and disk. Callers should invoke this once a source document
+// is known to be gone so its blob doesn't outlive it indefinitely.
+func (fc *FileCache) Delete(sourcePath string, modTime time.Time) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.deleteLocked(keyFor(sourcePath, modTime))
+}
+
+func (fc *FileCache) deleteLocked(key string) {
+	entry, ok := fc.entries[key]
+	if !ok {
+		return
+	}
+	delete(fc.entries, key)
+	fc.curBytes -= int64(len(entry.Data))
+	if elem, ok := fc.elems[key]; ok {
+		fc.order.Remove(elem)
+		delete(fc.elems, key)
+	}
+	os.Remove(fc.blobPath(key))
+	os.Remove(fc.metaPath(key))
+}
+
+// evictLocked removes least-recently-used entries until curBytes fits
+// within maxBytes. Callers must hold fc.mu.
+func (fc *FileCache) evictLocked() {
+	if fc.maxBytes <= 0 {
+		return
+	}
+	for fc.curBytes > fc.maxBytes {
+		back := fc.order.Back()
+		if back == nil {
+			return
+		}
+		fc.deleteLocked(back.Value.(string))
+	}
+}
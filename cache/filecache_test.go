@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFileCachePutGet(t *testing.T) {
+	fc, err := NewFileCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewFileCache: %v", err)
+	}
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry, err := fc.Put("doc.pdf", modTime, []byte("hello world"))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if entry.SHA256 == "" {
+		t.Error("Put: expected a non-empty SHA256")
+	}
+
+	got, ok := fc.Get("doc.pdf", modTime)
+	if !ok {
+		t.Fatal("Get: expected a hit for the just-written entry")
+	}
+	if string(got.Data) != "hello world" {
+		t.Errorf("Get: Data = %q, want %q", got.Data, "hello world")
+	}
+	if got.SHA256 != entry.SHA256 {
+		t.Errorf("Get: SHA256 = %v, want %v", got.SHA256, entry.SHA256)
+	}
+
+	if _, ok := fc.Get("doc.pdf", modTime.Add(time.Second)); ok {
+		t.Error("Get: a changed modTime should miss the cache")
+	}
+}
+
+func TestFileCacheSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	fc, err := NewFileCache(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileCache: %v", err)
+	}
+	if _, err := fc.Put("doc.pdf", modTime, []byte("persisted bytes")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	reopened, err := NewFileCache(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileCache (reopen): %v", err)
+	}
+	entry, ok := reopened.Get("doc.pdf", modTime)
+	if !ok {
+		t.Fatal("Get after reopen: expected a hit")
+	}
+	if string(entry.Data) != "persisted bytes" {
+		t.Errorf("Get after reopen: Data = %q, want %q", entry.Data, "persisted bytes")
+	}
+	// the source document's mtime must round-trip exactly, not just the
+	// blob file's own on-disk write time.
+	if !entry.ModTime.Equal(modTime) {
+		t.Errorf("Get after reopen: ModTime = %v, want %v", entry.ModTime, modTime)
+	}
+}
+
+func TestFileCacheDelete(t *testing.T) {
+	fc, err := NewFileCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewFileCache: %v", err)
+	}
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := fc.Put("doc.pdf", modTime, []byte("bytes")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	fc.Delete("doc.pdf", modTime)
+
+	if _, ok := fc.Get("doc.pdf", modTime); ok {
+		t.Error("Get after Delete: expected a miss")
+	}
+}
+
+func TestFileCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	fc, err := NewFileCache(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewFileCache: %v", err)
+	}
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := fc.Put("a.pdf", modTime, []byte("12345")); err != nil {
+		t.Fatalf("Put a.pdf: %v", err)
+	}
+	if _, err := fc.Put("b.pdf", modTime, []byte("12345")); err != nil {
+		t.Fatalf("Put b.pdf: %v", err)
+	}
+	// touch a.pdf so it's more recently used than b.pdf when c.pdf arrives
+	// and pushes the cache over its 10-byte budget
+	if _, ok := fc.Get("a.pdf", modTime); !ok {
+		t.Fatal("Get a.pdf: expected a hit before eviction")
+	}
+	if _, err := fc.Put("c.pdf", modTime, []byte("12345")); err != nil {
+		t.Fatalf("Put c.pdf: %v", err)
+	}
+
+	if _, ok := fc.Get("a.pdf", modTime); !ok {
+		t.Error("Get a.pdf: expected the recently-touched entry to survive eviction")
+	}
+	if _, ok := fc.Get("c.pdf", modTime); !ok {
+		t.Error("Get c.pdf: expected the just-written entry to survive eviction")
+	}
+	// b.pdf is evicted since it's the least-recently-used of the three and
+	// all three no longer fit the 10-byte budget
+	if _, ok := fc.Get("b.pdf", modTime); ok {
+		t.Error("Get b.pdf: expected the untouched entry to have been evicted")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/mpetri/bananapod-srv/watch"
+	"golang.org/x/net/context"
+)
+
+// eventHub fans out archive deltas to every client currently connected to
+// /events over Server-Sent Events.
+type eventHub struct {
+	mu      sync.Mutex
+	clients map[chan watch.Delta]bool
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{clients: make(map[chan watch.Delta]bool)}
+}
+
+func (h *eventHub) subscribe() chan watch.Delta {
+	ch := make(chan watch.Delta, 4)
+	h.mu.Lock()
+	h.clients[ch] = true
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *eventHub) unsubscribe(ch chan watch.Delta) {
+	h.mu.Lock()
+	delete(h.clients, ch)
+	h.mu.Unlock()
+	close(ch)
+}
+
+func (h *eventHub) broadcast(delta watch.Delta) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- delta:
+		default:
+			log.Printf("events: dropping update for slow client")
+		}
+	}
+}
+
+// Events streams archive deltas (added/removed/changed documents) to the
+// client as Server-Sent Events, so the UI can stay in sync without polling.
+func Events(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	closeNotifier, ok := w.(http.CloseNotifier)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := archiveEvents.subscribe()
+	defer archiveEvents.unsubscribe(ch)
+
+	for {
+		select {
+		case delta, ok := <-ch:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(delta)
+			if err != nil {
+				log.Printf("events: error encoding delta: %v", err.Error())
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			flusher.Flush()
+		case <-closeNotifier.CloseNotify():
+			return
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Package export renders derivative representations of an archive document
+// (page images, plain text, ...) behind a small DocExporter interface, so a
+// new output format can be added by registering another entry in Exporters
+// without touching the HTTP handler that serves /doc/:id.
+package export
+
+import (
+	"io"
+)
+
+// DocInfo is the subset of ArchiveDoc an exporter needs to render a
+// document, kept separate from the main package's type to avoid a circular
+// import.
+type DocInfo struct {
+	Id       uint64
+	Name     string
+	FilePath string
+	Pages    int
+	// Content is the already-extracted per-page text, base64-encoded with
+	// pages joined by "\f" (the same encoding ArchiveDoc.Content uses), so
+	// exporters that only need text don't have to re-run poppler/OCR.
+	Content string
+}
+
+// Exporter renders a document in a single output format.
+type Exporter interface {
+	// ContentType is the MIME type to send for this format's response.
+	ContentType() string
+	// FileExtension is used to build a Content-Disposition filename.
+	FileExtension() string
+	// Export writes doc's rendered representation to w.
+	Export(w io.Writer, doc DocInfo) error
+}
+
+// Exporters maps an output `type` query parameter to the Exporter that
+// handles it.
+var Exporters = map[string]Exporter{
+	"tar":  TarExporter{},
+	"text": TextExporter{},
+	"zip":  ZipExporter{},
+}
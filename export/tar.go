@@ -0,0 +1,49 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"image/png"
+	"io"
+
+	"github.com/mpetri/go-poppler"
+)
+
+// TarExporter renders every page to a PNG and streams them as a tar archive.
+type TarExporter struct{}
+
+func (TarExporter) ContentType() string   { return "application/x-tar" }
+func (TarExporter) FileExtension() string { return "tar" }
+
+func (TarExporter) Export(w io.Writer, doc DocInfo) error {
+	pdfDoc, err := poppler.Open(doc.FilePath)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	for i := 0; i < doc.Pages; i++ {
+		page := pdfDoc.GetPage(i)
+		pageImage := page.Render(300)
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, pageImage); err != nil {
+			return err
+		}
+
+		header := &tar.Header{
+			Name: fmt.Sprintf("page-%04d.png", i+1),
+			Mode: 0644,
+			Size: int64(buf.Len()),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
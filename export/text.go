@@ -0,0 +1,24 @@
+package export
+
+import (
+	"encoding/base64"
+	"io"
+)
+
+// TextExporter renders the plain UTF-8 text of every page. The text itself
+// (including any OCR fallback) was already extracted once during ingestion
+// and is reused here via doc.Content rather than re-running poppler/tesseract
+// per request.
+type TextExporter struct{}
+
+func (TextExporter) ContentType() string   { return "text/plain; charset=utf-8" }
+func (TextExporter) FileExtension() string { return "txt" }
+
+func (TextExporter) Export(w io.Writer, doc DocInfo) error {
+	decoded, err := base64.StdEncoding.DecodeString(doc.Content)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(decoded)
+	return err
+}
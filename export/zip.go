@@ -0,0 +1,57 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"image/jpeg"
+	"io"
+
+	"github.com/mpetri/go-poppler"
+)
+
+// ZipExporter renders every page to a JPEG and bundles them with a
+// metadata.json describing the source document into a zip archive.
+type ZipExporter struct{}
+
+func (ZipExporter) ContentType() string   { return "application/zip" }
+func (ZipExporter) FileExtension() string { return "zip" }
+
+type zipMetadata struct {
+	Id    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Pages int    `json:"pages"`
+}
+
+func (ZipExporter) Export(w io.Writer, doc DocInfo) error {
+	pdfDoc, err := poppler.Open(doc.FilePath)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	for i := 0; i < doc.Pages; i++ {
+		page := pdfDoc.GetPage(i)
+		pageImage := page.Render(300)
+
+		entry, err := zw.Create(fmt.Sprintf("page-%04d.jpg", i+1))
+		if err != nil {
+			return err
+		}
+		if err := jpeg.Encode(entry, pageImage, nil); err != nil {
+			return err
+		}
+	}
+
+	metaEntry, err := zw.Create("metadata.json")
+	if err != nil {
+		return err
+	}
+	meta := zipMetadata{Id: doc.Id, Name: doc.Name, Pages: doc.Pages}
+	if err := json.NewEncoder(metaEntry).Encode(meta); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,168 @@
+// Package ingest implements a bounded worker pool for parsing archive
+// documents, so a large archive doesn't serialize every poppler call inside
+// the goroutine handling an HTTP request. Each submitted document gets its
+// own timeout and its progress is tracked for the /ingest/status endpoint.
+// Total concurrent poppler/OCR renders - including ones abandoned after
+// their timeout fired - stay bounded via Pool.Acquire/Release.
+package ingest
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Status is the lifecycle state of a single document's ingestion.
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusParsed  Status = "parsed"
+	StatusFailed  Status = "failed"
+)
+
+// DocStatus is the ingestion outcome tracked for a single document.
+type DocStatus struct {
+	Path  string
+	State Status
+	Err   error
+}
+
+// Progress is the aggregate ingestion snapshot returned by GET
+// /ingest/status.
+type Progress struct {
+	Total    int     `json:"total"`
+	Parsed   int     `json:"parsed"`
+	Failed   int     `json:"failed"`
+	InFlight int     `json:"in_flight"`
+	ETA      float64 `json:"eta"`
+}
+
+// ProcessFunc parses a single document and must respect ctx's deadline. If
+// it hands the real work to a background goroutine to do so, that goroutine
+// must guard its work with the owning Pool's Acquire/Release so abandoned
+// renders still count against the concurrency bound.
+type ProcessFunc func(ctx context.Context, path string) error
+
+// Pool ingests documents with a fixed number of worker goroutines, a
+// per-document timeout, and a status store used to answer progress queries.
+type Pool struct {
+	timeout time.Duration
+	process ProcessFunc
+	workers int
+
+	jobs chan string
+
+	// renderSem bounds how many documents may be doing real poppler/OCR work
+	// at once. process has no way to abort mid-call once ctx expires (the
+	// underlying poppler/tesseract calls are synchronous C bindings with no
+	// cancellation hook), so a timed-out call keeps running in the
+	// background instead of freeing its worker's slot. renderSem is
+	// acquired by that background work itself via Acquire/Release, so a new
+	// render can't start until total concurrent renders - including ones
+	// already orphaned by a previous timeout - drops back under workers.
+	renderSem chan struct{}
+
+	mu       sync.Mutex
+	statuses map[string]*DocStatus
+	inFlight int32
+
+	totalDuration time.Duration
+	doneCount     int64
+}
+
+// NewPool starts workers goroutines, each pulling paths off the internal
+// job queue and running process on them with a per-document timeout.
+func NewPool(workers int, timeout time.Duration, process ProcessFunc) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{
+		timeout:   timeout,
+		process:   process,
+		workers:   workers,
+		jobs:      make(chan string, workers*4),
+		renderSem: make(chan struct{}, workers),
+		statuses:  make(map[string]*DocStatus),
+	}
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+// Acquire blocks until a render slot is free. process implementations that
+// hand the real work off to a background goroutine (to honor ctx's
+// deadline) must Acquire before starting that work and Release when it
+// finishes, even if the caller already gave up waiting on it.
+func (p *Pool) Acquire() { p.renderSem <- struct{}{} }
+
+// Release returns a slot acquired via Acquire.
+func (p *Pool) Release() { <-p.renderSem }
+
+func (p *Pool) worker() {
+	for path := range p.jobs {
+		p.run(path)
+	}
+}
+
+func (p *Pool) run(path string) {
+	atomic.AddInt32(&p.inFlight, 1)
+	defer atomic.AddInt32(&p.inFlight, -1)
+
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	err := p.process(ctx, path)
+	cancel()
+	elapsed := time.Since(started)
+
+	status := &DocStatus{Path: path, State: StatusParsed}
+	if err != nil {
+		status.State = StatusFailed
+		status.Err = err
+	}
+
+	p.mu.Lock()
+	p.statuses[path] = status
+	p.totalDuration += elapsed
+	p.doneCount++
+	p.mu.Unlock()
+}
+
+// Enqueue submits path for ingestion. It is marked pending immediately so
+// Progress reflects it even before a worker picks it up.
+func (p *Pool) Enqueue(path string) {
+	p.mu.Lock()
+	p.statuses[path] = &DocStatus{Path: path, State: StatusPending}
+	p.mu.Unlock()
+	p.jobs <- path
+}
+
+// Progress summarizes ingestion status across every document ever
+// submitted to the pool, estimating a completion ETA from the average
+// per-document duration observed so far.
+func (p *Pool) Progress() Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var progress Progress
+	progress.Total = len(p.statuses)
+	for _, s := range p.statuses {
+		switch s.State {
+		case StatusParsed:
+			progress.Parsed++
+		case StatusFailed:
+			progress.Failed++
+		}
+	}
+	progress.InFlight = int(atomic.LoadInt32(&p.inFlight))
+
+	remaining := progress.Total - progress.Parsed - progress.Failed
+	if p.doneCount > 0 && remaining > 0 {
+		avg := p.totalDuration / time.Duration(p.doneCount)
+		progress.ETA = avg.Seconds() * float64(remaining) / float64(p.workers)
+	}
+	return progress
+}
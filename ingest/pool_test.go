@@ -0,0 +1,84 @@
+package ingest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestPoolProgressTracksParsedAndFailed(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewPool(2, time.Second, func(ctx context.Context, path string) error {
+		<-release
+		if path == "bad.pdf" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	pool.Enqueue("good.pdf")
+	pool.Enqueue("bad.pdf")
+
+	if progress := pool.Progress(); progress.Total != 2 || progress.Parsed != 0 || progress.Failed != 0 {
+		t.Fatalf("Progress before workers run = %+v, want Total 2, Parsed 0, Failed 0", progress)
+	}
+
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	var progress Progress
+	for time.Now().Before(deadline) {
+		progress = pool.Progress()
+		if progress.Parsed+progress.Failed == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if progress.Total != 2 || progress.Parsed != 1 || progress.Failed != 1 {
+		t.Errorf("Progress after completion = %+v, want Total 2, Parsed 1, Failed 1", progress)
+	}
+	if progress.InFlight != 0 {
+		t.Errorf("Progress after completion: InFlight = %v, want 0", progress.InFlight)
+	}
+}
+
+func TestPoolAcquireBoundsConcurrentRenders(t *testing.T) {
+	pool := NewPool(1, time.Millisecond, func(ctx context.Context, path string) error {
+		return nil
+	})
+
+	pool.Acquire()
+	defer pool.Release()
+
+	acquired := make(chan struct{})
+	go func() {
+		pool.Acquire()
+		close(acquired)
+		pool.Release()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Acquire: expected a second Acquire to block while the only slot is held")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestPoolEnqueueMarksPendingImmediately(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewPool(1, time.Second, func(ctx context.Context, path string) error {
+		<-release
+		return nil
+	})
+	defer close(release)
+
+	pool.Enqueue("doc.pdf")
+
+	progress := pool.Progress()
+	if progress.Total != 1 || progress.Parsed != 0 {
+		t.Errorf("Progress immediately after Enqueue = %+v, want Total 1, Parsed 0", progress)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// IngestStatus reports ingestPool's aggregate progress, so clients can show
+// a scan/ingest progress bar instead of polling /alldocs/ for completeness.
+func IngestStatus(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(ingestPool.Progress())
+}
+
+// IngestRescan re-globs the archive and enqueues every document onto
+// ingestPool, picking up files the watcher might have missed (e.g. while the
+// server was stopped). It returns immediately; progress is available from
+// IngestStatus.
+func IngestRescan(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	go enqueueArchive()
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -1,19 +1,23 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/base64"
+	"encoding/gob"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"github.com/goji/httpauth"
+	"github.com/mpetri/bananapod-srv/auth"
+	"github.com/mpetri/bananapod-srv/cache"
+	"github.com/mpetri/bananapod-srv/export"
+	"github.com/mpetri/bananapod-srv/ingest"
+	"github.com/mpetri/bananapod-srv/search"
+	"github.com/mpetri/bananapod-srv/watch"
 	"github.com/mpetri/go-poppler"
 	"goji.io"
 	"goji.io/pat"
 	"golang.org/x/net/context"
 	"hash/fnv"
-	"image"
 	"image/jpeg"
 	"log"
 	"net/http"
@@ -51,12 +55,6 @@ type ArchiveDoc struct {
 }
 type ArchiveDocs []*ArchiveDoc
 
-type ArchiveDocThumb struct {
-	Id       uint64
-	Thumb    image.Image
-	Encoding []byte
-}
-
 func (slice ArchiveDocs) Len() int {
 	return len(slice)
 }
@@ -74,27 +72,33 @@ func (a *ArchiveDoc) String() string {
 }
 
 var (
-	archivePath        = flag.String("archive", "", "path to the document archive")
-	port               = flag.Int("port", 8000, "Server port")
-	docCache           = make(map[uint64]*ArchiveDoc)
-	docCacheMutex      = &sync.Mutex{}
-	docThumbCache      = make(map[uint64]*ArchiveDocThumb)
-	docThumbCacheMutex = &sync.Mutex{}
+	archivePath   = flag.String("archive", "", "path to the document archive")
+	port          = flag.Int("port", 8000, "Server port")
+	indexPath     = flag.String("index", "archive.idx", "path to the on-disk search index")
+	cacheDir      = flag.String("cache-dir", "cache", "directory for the persistent document/thumbnail cache")
+	cacheMaxBytes = flag.Int64("cache-max-bytes", 1<<30, "maximum bytes each persistent cache (meta/docs/thumbs) may hold before evicting least-recently-used entries")
+	authMode      = flag.String("auth", "basic", "authentication mode: basic, session-cookie, or jwt-bearer")
+	authConfig    = flag.String("auth-config", "users.json", "path to the users store file")
+	ingestWorkers = flag.Int("ingest-workers", 4, "number of concurrent document ingestion workers")
+	docCache      = make(map[uint64]*ArchiveDoc)
+	docCacheMutex = &sync.Mutex{}
+	searchIndex   *search.Index
+	metaCache     *cache.FileCache
+	docBytesCache *cache.FileCache
+	docThumbCache *cache.FileCache
+	archiveEvents *eventHub
+	ingestPool    *ingest.Pool
 )
 
-func ProcessDocument(filepath string) (doc *ArchiveDoc, err error) {
-	// generate ID from path
+// docIdFor derives the stable document id from its archive path.
+func docIdFor(filepath string) uint64 {
 	h := fnv.New64a()
 	h.Write([]byte(filepath))
-	docId := h.Sum64()
+	return h.Sum64()
+}
 
-	// check cache
-	docCacheMutex.Lock()
-	prestoredDoc, ok := docCache[docId]
-	docCacheMutex.Unlock()
-	if ok {
-		return prestoredDoc, nil
-	}
+func ProcessDocument(filepath string) (doc *ArchiveDoc, err error) {
+	docId := docIdFor(filepath)
 
 	// get file stats
 	file, err := os.Open(filepath)
@@ -109,6 +113,19 @@ func ProcessDocument(filepath string) (doc *ArchiveDoc, err error) {
 	fileSize := fi.Size()
 	modTime := fi.ModTime()
 
+	// the persistent cache is keyed by (path, modTime), so a restart does
+	// not force every document to be re-parsed via poppler
+	if entry, ok := metaCache.Get(filepath, modTime); ok {
+		var cachedDoc ArchiveDoc
+		if err := gob.NewDecoder(bytes.NewReader(entry.Data)).Decode(&cachedDoc); err == nil {
+			docCacheMutex.Lock()
+			docCache[cachedDoc.Id] = &cachedDoc
+			docCacheMutex.Unlock()
+			reindexCachedDoc(&cachedDoc, filepath)
+			return &cachedDoc, nil
+		}
+	}
+
 	// try to parse file time
 	var year, month, day, hour, minute, second int
 	numparsed, err := fmt.Sscanf(path.Base(filepath), "%d_%d_%d_%d_%d_%d", &year, &month, &day, &hour, &minute, &second)
@@ -124,10 +141,32 @@ func ProcessDocument(filepath string) (doc *ArchiveDoc, err error) {
 		return nil, err
 	}
 	numPages := pdfDoc.GetNPages()
-
-	firstPage := pdfDoc.GetPage(0)
-	pageText := firstPage.Text()
-	encodedText := base64.StdEncoding.EncodeToString([]byte(pageText))
+	category := path.Base(path.Dir(filepath))
+
+	pageTexts := make([]string, numPages)
+	for i := 0; i < numPages; i++ {
+		page := pdfDoc.GetPage(i)
+		pageText := page.Text()
+		if strings.TrimSpace(pageText) == "" {
+			ocrText, ocrErr := search.OCRImage(page.Render(300))
+			if ocrErr != nil {
+				log.Printf("OCR fallback failed for %v page %v: %v", filepath, i, ocrErr.Error())
+			} else {
+				pageText = ocrText
+			}
+		}
+		pageTexts[i] = pageText
+		if searchIndex != nil {
+			searchIndex.AddPage(search.PageText{
+				DocId:    docId,
+				Page:     i,
+				Category: category,
+				DocDate:  fileTime,
+				Text:     pageText,
+			})
+		}
+	}
+	encodedText := base64.StdEncoding.EncodeToString([]byte(strings.Join(pageTexts, "\f")))
 
 	newDoc := &ArchiveDoc{
 		Id:         docId,
@@ -140,7 +179,14 @@ func ProcessDocument(filepath string) (doc *ArchiveDoc, err error) {
 		Content:    encodedText,
 	}
 
-	// add to cache
+	var encodedDoc bytes.Buffer
+	if err := gob.NewEncoder(&encodedDoc).Encode(newDoc); err != nil {
+		log.Printf("Error encoding document for cache: %v", err.Error())
+	} else if _, err := metaCache.Put(filepath, modTime, encodedDoc.Bytes()); err != nil {
+		log.Printf("Error persisting document to cache: %v", err.Error())
+	}
+
+	// add to in-process index for fast id -> doc lookups
 	docCacheMutex.Lock()
 	docCache[docId] = newDoc
 	docCacheMutex.Unlock()
@@ -148,7 +194,67 @@ func ProcessDocument(filepath string) (doc *ArchiveDoc, err error) {
 	return newDoc, nil
 }
 
-func AllDocs(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+// reindexCachedDoc re-populates searchIndex for a document served from
+// metaCache. The meta cache and archive.idx are persisted independently, and
+// ProcessDocument only adds pages to searchIndex in its fresh-parse branch
+// above, so without this a cache hit would silently drop the document from
+// /search until its mtime changes.
+func reindexCachedDoc(doc *ArchiveDoc, filepath string) {
+	if searchIndex == nil {
+		return
+	}
+	decoded, err := base64.StdEncoding.DecodeString(doc.Content)
+	if err != nil {
+		log.Printf("Error decoding cached content for %v: %v", filepath, err.Error())
+		return
+	}
+	category := path.Base(path.Dir(filepath))
+	for i, pageText := range strings.Split(string(decoded), "\f") {
+		searchIndex.AddPage(search.PageText{
+			DocId:    doc.Id,
+			Page:     i,
+			Category: category,
+			DocDate:  doc.FileDate,
+			Text:     pageText,
+		})
+	}
+}
+
+// ingestDocument adapts ProcessDocument to ingest.ProcessFunc: it runs the
+// (blocking, poppler-backed) parse in its own goroutine so a slow document
+// can be abandoned once ctx's per-document timeout expires, and persists
+// the search index once the document lands in docCache. The background
+// goroutine still holds an ingestPool render slot for as long as it
+// actually runs, even after ingestDocument itself has given up on it, so an
+// abandoned render still counts against -ingest-workers instead of running
+// unbounded in the background.
+func ingestDocument(ctx context.Context, filepath string) error {
+	done := make(chan error, 1)
+	go func() {
+		ingestPool.Acquire()
+		defer ingestPool.Release()
+		_, err := ProcessDocument(filepath)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			if saveErr := searchIndex.Save(); saveErr != nil {
+				log.Printf("Error saving search index: %v", saveErr.Error())
+			}
+		}
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueueArchive globs the archive once and enqueues every document onto
+// ingestPool. It is used both to seed the index at startup and to serve a
+// manual /ingest/rescan sweep; parsing itself happens asynchronously, so
+// /alldocs/ always serves whatever docCache has built up so far in O(1).
+func enqueueArchive() {
 	pattern := fmt.Sprintf("%v/*/*.pdf", *archivePath)
 	archiveFiles, err := filepath.Glob(pattern)
 	if err != nil {
@@ -156,83 +262,202 @@ func AllDocs(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	}
 	log.Printf("Found %v documents (%v)", len(archiveFiles), pattern)
 
-	// (2) parse docs
-	log.Printf("Parse %v documents", len(archiveFiles))
-	archiveDocs := make(ArchiveDocs, 0, 0)
 	for _, file := range archiveFiles {
-		newDoc, err := ProcessDocument(file)
-		if err != nil {
-			log.Printf("Error parsing document %v: %v", file, err.Error())
-		} else {
-			archiveDocs = append(archiveDocs, newDoc)
+		ingestPool.Enqueue(file)
+	}
+}
+
+// removeDocument evicts a deleted file's document from docCache and the
+// search index, called when the watcher reports a removal.
+func removeDocument(filepath string) {
+	docId := docIdFor(filepath)
+
+	docCacheMutex.Lock()
+	doc, ok := docCache[docId]
+	delete(docCache, docId)
+	docCacheMutex.Unlock()
+
+	// the file is already gone, so its blobs are keyed by the mtime it had
+	// when last ingested (the same modTime ProcessDocument/DocContent/
+	// DocThumbnail used when they Put it), which CreateDate still holds
+	if ok {
+		metaCache.Delete(filepath, doc.CreateDate)
+		docBytesCache.Delete(filepath, doc.CreateDate)
+		docThumbCache.Delete(filepath, doc.CreateDate)
+	}
+
+	searchIndex.RemoveDoc(docId)
+	if err := searchIndex.Save(); err != nil {
+		log.Printf("Error saving search index: %v", err.Error())
+	}
+}
+
+// watchArchive applies debounced archive deltas from w, enqueueing added or
+// changed documents onto ingestPool and evicting removed ones immediately,
+// then fans each delta out to connected /events clients.
+func watchArchive(w *watch.Watcher) {
+	for delta := range w.Deltas() {
+		for _, file := range delta.Added {
+			ingestPool.Enqueue(file)
+		}
+		for _, file := range delta.Changed {
+			ingestPool.Enqueue(file)
+		}
+		for _, file := range delta.Removed {
+			removeDocument(file)
 		}
+
+		archiveEvents.broadcast(delta)
 	}
+}
 
-	log.Printf("Sort %v documents", len(archiveDocs))
-	sort.Sort(archiveDocs)
+func AllDocs(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	docCacheMutex.Lock()
+	archiveDocs := make(ArchiveDocs, 0, len(docCache))
+	for _, doc := range docCache {
+		archiveDocs = append(archiveDocs, doc)
+	}
+	docCacheMutex.Unlock()
 
-	log.Printf("Output %v documents", len(archiveDocs))
+	sort.Sort(archiveDocs)
 	json.NewEncoder(w).Encode(archiveDocs)
 }
 
 func Categories(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	pattern := fmt.Sprintf("%v/*", *archivePath)
-	archiveCategories, err := filepath.Glob(pattern)
-	if err != nil {
-		log.Fatal("Error finding archive content: %v", err.Error())
+	counts := make(map[string]int)
+
+	docCacheMutex.Lock()
+	for _, doc := range docCache {
+		cat := path.Base(path.Dir(doc.FilePath))
+		counts[cat]++
 	}
-	log.Printf("Found %v categories (%v)", len(archiveCategories), pattern)
-	archiveCats := make([]*ArchiveCategory, 0, 0)
-	for _, category := range archiveCategories {
-		cat := path.Base(category)
-		isHidden := strings.HasPrefix(cat, ".")
-		if isHidden == false {
-			f, err := os.Open(category)
-			if err != nil {
-				log.Fatal("Error finding parsing categories: %v", err.Error())
-			}
-			defer f.Close()
-			fi, err := f.Stat()
-			if err != nil {
-				log.Fatal("Error finding parsing categories: %v", err.Error())
-			}
-			mode := fi.Mode()
-			if mode.IsDir() == true {
-				fileinCat, err := filepath.Glob(category + "/*.pdf")
-				if err != nil {
-					log.Fatal("Error finding parsing categories: %v", err.Error())
-				}
-				newCat := &ArchiveCategory{
-					Name:     cat,
-					Elements: len(fileinCat),
-				}
-				archiveCats = append(archiveCats, newCat)
-			}
-		}
+	docCacheMutex.Unlock()
+
+	archiveCats := make([]*ArchiveCategory, 0, len(counts))
+	for cat, elements := range counts {
+		archiveCats = append(archiveCats, &ArchiveCategory{Name: cat, Elements: elements})
 	}
 
 	json.NewEncoder(w).Encode(archiveCats)
 }
 
+func Search(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		http.Error(w, "missing required query parameter: q", http.StatusBadRequest)
+		return
+	}
+	category := r.URL.Query().Get("category")
+
+	var from, to time.Time
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid from date: %v", err.Error()), http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid to date: %v", err.Error()), http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+
+	hits := searchIndex.Search(q, category, from, to)
+	json.NewEncoder(w).Encode(hits)
+}
+
+// serveCached writes the validators for a cache.Entry and honors
+// If-None-Match / If-Modified-Since, returning 304 Not Modified without a
+// body when the client's copy is still fresh.
+func serveCached(w http.ResponseWriter, r *http.Request, entry *cache.Entry, contentType string) {
+	etag := fmt.Sprintf("%q", entry.SHA256)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", entry.ModTime.UTC().Format(http.TimeFormat))
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" {
+		if sinceTime, err := http.ParseTime(since); err == nil && !entry.ModTime.Truncate(time.Second).After(sinceTime) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Write(entry.Data)
+}
+
 func DocContent(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	id := pat.Param(ctx, "id")
+	docIdStr := pat.Param(ctx, "id")
+	docId, err := strconv.ParseUint(docIdStr, 10, 64)
+	if err != nil {
+		log.Printf("Invalid docid: %v", docIdStr)
+		http.Error(w, fmt.Sprintf("Invalid docid: %v", docIdStr), http.StatusBadRequest)
+		return
+	}
 
 	docCacheMutex.Lock()
 	prestoredDoc, ok := docCache[docId]
 	docCacheMutex.Unlock()
 	if !ok {
 		log.Printf("Requesting unknown document: %v", docId)
-		fmt.Fprintf(w, "Requesting unknown document: %v", docId)
+		http.Error(w, fmt.Sprintf("Requesting unknown document: %v", docId), http.StatusNotFound)
+		return
+	}
+
+	outputType := r.URL.Query().Get("type")
+	if outputType != "" && outputType != "pdf" {
+		exporter, ok := export.Exporters[outputType]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unsupported output type: %v", outputType), http.StatusBadRequest)
+			return
+		}
+
+		baseName := strings.TrimSuffix(prestoredDoc.Name, filepath.Ext(prestoredDoc.Name))
+		w.Header().Set("Content-Type", exporter.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%v.%v"`, baseName, exporter.FileExtension()))
+
+		docInfo := export.DocInfo{Id: prestoredDoc.Id, Name: prestoredDoc.Name, FilePath: prestoredDoc.FilePath, Pages: prestoredDoc.Pages, Content: prestoredDoc.Content}
+		if err := exporter.Export(w, docInfo); err != nil {
+			log.Printf("Error exporting document %v as %v: %v", prestoredDoc.FilePath, outputType, err.Error())
+		}
+		return
+	}
+
+	fi, err := os.Stat(prestoredDoc.FilePath)
+	if err != nil {
+		log.Printf("Error stat'ing document: %v", prestoredDoc.FilePath)
+		http.Error(w, "Error reading document", http.StatusInternalServerError)
+		return
+	}
+
+	entry, ok := docBytesCache.Get(prestoredDoc.FilePath, fi.ModTime())
+	if !ok {
+		in, err := os.Open(prestoredDoc.FilePath)
+		if err != nil {
+			log.Printf("Error opening document: %v", prestoredDoc.FilePath)
+			http.Error(w, "Error reading document", http.StatusInternalServerError)
+			return
+		}
+		entry, err = docBytesCache.PutReader(prestoredDoc.FilePath, fi.ModTime(), in)
+		in.Close()
+		if err != nil {
+			log.Printf("Error transfering document: %v", prestoredDoc.FilePath)
+			http.Error(w, "Error reading document", http.StatusInternalServerError)
+			return
+		}
 	}
-	in, err := os.Open(prestoredDoc.FilePath)
-    if err != nil {
-        return
-    }
-    defer in.Close()
 
-	if _, err = io.Copy(w, in); err != nil {
-        log.Printf("Error transfering document: %v", prestoredDoc.FilePath)
-    }
+	serveCached(w, r, entry, "application/pdf")
 }
 
 func DocThumbnail(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -240,14 +465,7 @@ func DocThumbnail(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	docId, err := strconv.ParseUint(docIdStr, 10, 64)
 	if err != nil {
 		log.Printf("Invalid docid: %v", docIdStr)
-		fmt.Fprintf(w, "Invalid docid: %v", docIdStr)
-	}
-
-	docThumbCacheMutex.Lock()
-	prestoredThumb, ok := docThumbCache[docId]
-	docThumbCacheMutex.Unlock()
-	if ok {
-		w.Write(prestoredThumb.Encoding)
+		http.Error(w, fmt.Sprintf("Invalid docid: %v", docIdStr), http.StatusBadRequest)
 		return
 	}
 
@@ -256,36 +474,48 @@ func DocThumbnail(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	docCacheMutex.Unlock()
 	if !ok {
 		log.Printf("Requesting unknown document: %v", docId)
-		fmt.Fprintf(w, "Requesting unknown document: %v", docId)
+		http.Error(w, fmt.Sprintf("Requesting unknown document: %v", docId), http.StatusNotFound)
+		return
 	}
 
-	log.Printf("Open pdf document: %v", prestoredDoc.FilePath)
-	pdfDoc, err := poppler.Open(prestoredDoc.FilePath)
+	fi, err := os.Stat(prestoredDoc.FilePath)
 	if err != nil {
-		log.Printf("Error opening PDF document for thumbnail creation: %v", err.Error())
-	}
-	log.Printf("Get first page of pdf document: %v", prestoredDoc.FilePath)
-	firstPage := pdfDoc.GetPage(0)
-	log.Printf("Render first page of document: %v", prestoredDoc.FilePath)
-	pageImage := firstPage.Render(300)
-
-	log.Printf("Encode as PNG document: %v", prestoredDoc.FilePath)
-	var b bytes.Buffer
-	buf := bufio.NewWriter(&b)
-	jpeg.Encode(buf, pageImage, nil)
-	log.Printf("Write to client document: %v", prestoredDoc.FilePath)
-
-	newThumb := &ArchiveDocThumb{
-		Id:       docId,
-		Thumb:    pageImage,
-		Encoding: b.Bytes(),
-	}
-	log.Printf("Store in cache document: %v", prestoredDoc.FilePath)
-	docThumbCacheMutex.Lock()
-	docThumbCache[docId] = newThumb
-	docThumbCacheMutex.Unlock()
-
-	b.WriteTo(w)
+		log.Printf("Error stat'ing document: %v", prestoredDoc.FilePath)
+		http.Error(w, "Error reading document", http.StatusInternalServerError)
+		return
+	}
+
+	entry, ok := docThumbCache.Get(prestoredDoc.FilePath, fi.ModTime())
+	if !ok {
+		log.Printf("Open pdf document: %v", prestoredDoc.FilePath)
+		pdfDoc, err := poppler.Open(prestoredDoc.FilePath)
+		if err != nil {
+			log.Printf("Error opening PDF document for thumbnail creation: %v", err.Error())
+			http.Error(w, "Error reading document", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Get first page of pdf document: %v", prestoredDoc.FilePath)
+		firstPage := pdfDoc.GetPage(0)
+		log.Printf("Render first page of document: %v", prestoredDoc.FilePath)
+		pageImage := firstPage.Render(300)
+
+		log.Printf("Encode as JPEG document: %v", prestoredDoc.FilePath)
+		var b bytes.Buffer
+		if err := jpeg.Encode(&b, pageImage, nil); err != nil {
+			log.Printf("Error encoding thumbnail: %v", err.Error())
+			http.Error(w, "Error reading document", http.StatusInternalServerError)
+			return
+		}
+
+		entry, err = docThumbCache.PutReader(prestoredDoc.FilePath, fi.ModTime(), &b)
+		if err != nil {
+			log.Printf("Error caching thumbnail: %v", err.Error())
+			http.Error(w, "Error reading document", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	serveCached(w, r, entry, "image/jpeg")
 }
 
 func main() {
@@ -293,13 +523,99 @@ func main() {
 	log.Printf("Archive path = %v", *archivePath)
 	log.Printf("Listening port = %v", *port)
 
+	var err error
+	searchIndex, err = search.NewIndex(*indexPath)
+	if err != nil {
+		log.Fatal("Error loading search index: %v", err.Error())
+	}
+
+	metaCache, err = cache.NewFileCache(filepath.Join(*cacheDir, "meta"), *cacheMaxBytes)
+	if err != nil {
+		log.Fatal("Error opening document metadata cache: %v", err.Error())
+	}
+	docBytesCache, err = cache.NewFileCache(filepath.Join(*cacheDir, "docs"), *cacheMaxBytes)
+	if err != nil {
+		log.Fatal("Error opening document content cache: %v", err.Error())
+	}
+	docThumbCache, err = cache.NewFileCache(filepath.Join(*cacheDir, "thumbs"), *cacheMaxBytes)
+	if err != nil {
+		log.Fatal("Error opening thumbnail cache: %v", err.Error())
+	}
+
+	archiveEvents = newEventHub()
+	ingestPool = ingest.NewPool(*ingestWorkers, 30*time.Second, ingestDocument)
+
+	log.Printf("Scanning archive for the first time")
+	go enqueueArchive()
+
+	archiveWatcher, err := watch.New(*archivePath, 250*time.Millisecond)
+	if err != nil {
+		log.Fatal("Error starting archive watcher: %v", err.Error())
+	}
+	go watchArchive(archiveWatcher)
+
+	if err := setupAuth(); err != nil {
+		log.Fatal("Error setting up auth: %v", err.Error())
+	}
+
 	mux := goji.NewMux()
-	mux.Use(httpauth.SimpleBasicAuth("dave", "somepassword"))
-	mux.HandleFuncC(pat.Get("/alldocs/"), AllDocs)
-	mux.HandleFuncC(pat.Get("/categories/"), Categories)
-	mux.HandleFuncC(pat.Get("/thumbnail/:id"), DocThumbnail)
-	mux.HandleFuncC(pat.Get("/doc/:id"), DocContent)
+	mux.HandleFuncC(pat.Post("/login"), Login)
+	mux.HandleFuncC(pat.Post("/logout"), Logout)
+	mux.HandleFuncC(pat.Get("/me"), auth.Require(authenticator, auth.ScopeRead, Me))
+	mux.HandleFuncC(pat.Get("/admin/users"), auth.Require(authenticator, auth.ScopeAdmin, AdminUsers))
+	mux.HandleFuncC(pat.Post("/admin/users"), auth.Require(authenticator, auth.ScopeAdmin, AdminUsers))
+	mux.HandleFuncC(pat.Put("/admin/users"), auth.Require(authenticator, auth.ScopeAdmin, AdminUsers))
+	mux.HandleFuncC(pat.Delete("/admin/users"), auth.Require(authenticator, auth.ScopeAdmin, AdminUsers))
+	mux.HandleFuncC(pat.Get("/alldocs/"), auth.Require(authenticator, auth.ScopeRead, AllDocs))
+	mux.HandleFuncC(pat.Get("/categories/"), auth.Require(authenticator, auth.ScopeRead, Categories))
+	mux.HandleFuncC(pat.Get("/thumbnail/:id"), auth.Require(authenticator, auth.ScopeRead, DocThumbnail))
+	mux.HandleFuncC(pat.Get("/doc/:id"), auth.Require(authenticator, auth.ScopeRead, DocContent))
+	mux.HandleFuncC(pat.Get("/events"), auth.Require(authenticator, auth.ScopeRead, Events))
+	mux.HandleFuncC(pat.Get("/search"), auth.Require(authenticator, auth.ScopeRead, Search))
+	mux.HandleFuncC(pat.Get("/ingest/status"), auth.Require(authenticator, auth.ScopeRead, IngestStatus))
+	mux.HandleFuncC(pat.Post("/ingest/rescan"), auth.Require(authenticator, auth.ScopeAdmin, IngestRescan))
 
 	log.Printf("Listening on = 0.0.0.0:%v", *port)
 	http.ListenAndServe(fmt.Sprintf("0.0.0.0:%v", *port), mux)
 }
+
+// setupAuth loads the users store (honoring the BANANAPOD_AUTH_CONFIG,
+// BANANAPOD_ADMIN_USER and BANANAPOD_ADMIN_PASSWORD env var overrides) and
+// builds the Authenticator selected by -auth.
+func setupAuth() error {
+	authConfigPath := *authConfig
+	if v := os.Getenv("BANANAPOD_AUTH_CONFIG"); v != "" {
+		authConfigPath = v
+	}
+
+	adminUser := "admin"
+	if v := os.Getenv("BANANAPOD_ADMIN_USER"); v != "" {
+		adminUser = v
+	}
+	adminPassword := os.Getenv("BANANAPOD_ADMIN_PASSWORD")
+
+	var err error
+	userStore, err = auth.NewStore(authConfigPath, adminUser, adminPassword)
+	if err != nil {
+		return err
+	}
+
+	switch *authMode {
+	case "basic":
+		authenticator = auth.BasicAuthenticator{Store: userStore}
+	case "session-cookie":
+		sessionStore = auth.NewSessionStore(24 * time.Hour)
+		authenticator = auth.SessionAuthenticator{Store: userStore, Sessions: sessionStore}
+	case "jwt-bearer":
+		secret := os.Getenv("BANANAPOD_JWT_SECRET")
+		if secret == "" {
+			return fmt.Errorf("BANANAPOD_JWT_SECRET must be set when -auth=jwt-bearer")
+		}
+		jwtSecret = []byte(secret)
+		authenticator = auth.JWTAuthenticator{Store: userStore, Secret: jwtSecret}
+	default:
+		return fmt.Errorf("unknown auth mode: %v", *authMode)
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+// Package search implements a small hand-rolled inverted index over the
+// per-page text extracted from archive documents. It is intentionally
+// simple: postings are kept in memory and flushed to a single gob file on
+// disk so that a restart does not require re-parsing the whole archive.
+package search
+
+import (
+	"encoding/gob"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// PageText is a single page worth of extracted text, ready to be indexed.
+type PageText struct {
+	DocId    uint64
+	Page     int
+	Category string
+	DocDate  time.Time
+	Text     string
+}
+
+// Hit is a single ranked search result.
+type Hit struct {
+	DocId    uint64  `json:"docId"`
+	Page     int     `json:"page"`
+	Score    float64 `json:"score"`
+	Snippet  string  `json:"snippet"`
+	Category string  `json:"category"`
+}
+
+type posting struct {
+	DocId uint64
+	Page  int
+	Freq  int
+}
+
+type pageKey struct {
+	DocId uint64
+	Page  int
+}
+
+type pageRecord struct {
+	Category string
+	DocDate  time.Time
+	Text     string
+}
+
+// Index is a docId+page keyed inverted index, guarded by a mutex since it is
+// updated both from request handlers and the background watcher.
+type Index struct {
+	mu       sync.Mutex
+	path     string
+	postings map[string][]posting
+	pages    map[pageKey]pageRecord
+}
+
+// gobIndex is the on-disk representation of an Index.
+type gobIndex struct {
+	Postings map[string][]posting
+	Pages    map[pageKey]pageRecord
+}
+
+// NewIndex loads an existing on-disk index from path, or creates an empty
+// one if the file does not yet exist.
+func NewIndex(path string) (*Index, error) {
+	ix := &Index{
+		path:     path,
+		postings: make(map[string][]posting),
+		pages:    make(map[pageKey]pageRecord),
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var stored gobIndex
+	if err := gob.NewDecoder(f).Decode(&stored); err != nil {
+		return nil, err
+	}
+	ix.postings = stored.Postings
+	ix.pages = stored.Pages
+	return ix, nil
+}
+
+// AddPage tokenizes a page of text and merges it into the index, replacing
+// any previously indexed content for the same (docId, page).
+func (ix *Index) AddPage(pt PageText) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	key := pageKey{DocId: pt.DocId, Page: pt.Page}
+	ix.removePageLocked(key)
+
+	ix.pages[key] = pageRecord{Category: pt.Category, DocDate: pt.DocDate, Text: pt.Text}
+
+	freqs := make(map[string]int)
+	for _, term := range tokenize(pt.Text) {
+		freqs[term]++
+	}
+	for term, freq := range freqs {
+		ix.postings[term] = append(ix.postings[term], posting{DocId: pt.DocId, Page: pt.Page, Freq: freq})
+	}
+}
+
+// RemoveDoc removes every indexed page belonging to docId, used when a file
+// disappears from the archive.
+func (ix *Index) RemoveDoc(docId uint64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for key := range ix.pages {
+		if key.DocId == docId {
+			ix.removePageLocked(key)
+		}
+	}
+}
+
+func (ix *Index) removePageLocked(key pageKey) {
+	if _, ok := ix.pages[key]; !ok {
+		return
+	}
+	delete(ix.pages, key)
+	for term, postings := range ix.postings {
+		filtered := postings[:0]
+		for _, p := range postings {
+			if p.DocId != key.DocId || p.Page != key.Page {
+				filtered = append(filtered, p)
+			}
+		}
+		if len(filtered) == 0 {
+			delete(ix.postings, term)
+		} else {
+			ix.postings[term] = filtered
+		}
+	}
+}
+
+// Save flushes the index to its on-disk path.
+func (ix *Index) Save() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	tmpPath := ix.path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	stored := gobIndex{Postings: ix.postings, Pages: ix.pages}
+	if err := gob.NewEncoder(f).Encode(&stored); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, ix.path)
+}
+
+// Search runs a naive AND query over the indexed terms, optionally filtered
+// by category and a [from, to] document date range, and returns hits ranked
+// by summed term frequency.
+func (ix *Index) Search(query, category string, from, to time.Time) []Hit {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	scores := make(map[pageKey]float64)
+	for i, term := range terms {
+		matches := ix.postings[term]
+		if i == 0 {
+			for _, p := range matches {
+				scores[pageKey{DocId: p.DocId, Page: p.Page}] = float64(p.Freq)
+			}
+			continue
+		}
+		next := make(map[pageKey]float64)
+		for _, p := range matches {
+			key := pageKey{DocId: p.DocId, Page: p.Page}
+			if existing, ok := scores[key]; ok {
+				next[key] = existing + float64(p.Freq)
+			}
+		}
+		scores = next
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for key, score := range scores {
+		rec, ok := ix.pages[key]
+		if !ok {
+			continue
+		}
+		if category != "" && rec.Category != category {
+			continue
+		}
+		if !from.IsZero() && rec.DocDate.Before(from) {
+			continue
+		}
+		if !to.IsZero() && rec.DocDate.After(to) {
+			continue
+		}
+		hits = append(hits, Hit{
+			DocId:    key.DocId,
+			Page:     key.Page,
+			Score:    score,
+			Snippet:  snippet(rec.Text, terms[0]),
+			Category: rec.Category,
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	return hits
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// snippet returns a short excerpt of text around the first occurrence of
+// term, so search results have some context beyond a bare page number.
+func snippet(text, term string) string {
+	lower := strings.ToLower(text)
+	idx := strings.Index(lower, term)
+	if idx < 0 {
+		if len(text) > 140 {
+			return strings.TrimSpace(text[:140]) + "..."
+		}
+		return strings.TrimSpace(text)
+	}
+	start := idx - 60
+	if start < 0 {
+		start = 0
+	}
+	end := idx + len(term) + 60
+	if end > len(text) {
+		end = len(text)
+	}
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "..."
+	}
+	if end < len(text) {
+		suffix = "..."
+	}
+	return prefix + strings.TrimSpace(text[start:end]) + suffix
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndexAddPageAndSearch(t *testing.T) {
+	ix, err := NewIndex(filepath.Join(t.TempDir(), "archive.idx"))
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ix.AddPage(PageText{DocId: 1, Page: 0, Category: "reports", DocDate: base, Text: "the quick brown fox"})
+	ix.AddPage(PageText{DocId: 2, Page: 0, Category: "invoices", DocDate: base.AddDate(0, 0, 10), Text: "the quick fox jumps"})
+
+	hits := ix.Search("quick fox", "", time.Time{}, time.Time{})
+	if len(hits) != 2 {
+		t.Fatalf("Search: got %v hits, want 2", len(hits))
+	}
+	// doc 2 matches "quick" and "fox" each once, same as doc 1; both score 2.
+	if hits[0].Score != 2 || hits[1].Score != 2 {
+		t.Errorf("Search scores = %v, %v; want 2, 2", hits[0].Score, hits[1].Score)
+	}
+
+	if hits := ix.Search("quick", "invoices", time.Time{}, time.Time{}); len(hits) != 1 || hits[0].DocId != 2 {
+		t.Errorf("Search with category filter = %+v, want single hit for doc 2", hits)
+	}
+
+	if hits := ix.Search("quick", "", base.AddDate(0, 0, 5), time.Time{}); len(hits) != 1 || hits[0].DocId != 2 {
+		t.Errorf("Search with from filter = %+v, want single hit for doc 2", hits)
+	}
+
+	if hits := ix.Search("nonexistent term", "", time.Time{}, time.Time{}); len(hits) != 0 {
+		t.Errorf("Search for absent term = %+v, want no hits", hits)
+	}
+}
+
+func TestIndexRemoveDoc(t *testing.T) {
+	ix, err := NewIndex(filepath.Join(t.TempDir(), "archive.idx"))
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+
+	ix.AddPage(PageText{DocId: 1, Page: 0, Text: "alpha beta"})
+	ix.AddPage(PageText{DocId: 1, Page: 1, Text: "beta gamma"})
+	ix.RemoveDoc(1)
+
+	if hits := ix.Search("beta", "", time.Time{}, time.Time{}); len(hits) != 0 {
+		t.Errorf("Search after RemoveDoc = %+v, want no hits", hits)
+	}
+}
+
+func TestIndexSaveAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.idx")
+
+	ix, err := NewIndex(path)
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	ix.AddPage(PageText{DocId: 42, Page: 3, Category: "reports", Text: "persisted content"})
+	if err := ix.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := NewIndex(path)
+	if err != nil {
+		t.Fatalf("NewIndex (reload): %v", err)
+	}
+	hits := reloaded.Search("persisted", "", time.Time{}, time.Time{})
+	if len(hits) != 1 || hits[0].DocId != 42 || hits[0].Page != 3 {
+		t.Errorf("Search after reload = %+v, want single hit for doc 42 page 3", hits)
+	}
+}
@@ -0,0 +1,42 @@
+package search
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// OCRImage shells out to tesseract to recover text from a rendered page
+// image. It is used as a fallback for scanned PDFs whose embedded text
+// layer is empty.
+func OCRImage(img image.Image) (string, error) {
+	tmpFile, err := ioutil.TempFile("", "bananapod-ocr-*.png")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := png.Encode(tmpFile, img); err != nil {
+		tmpFile.Close()
+		return "", err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", err
+	}
+
+	outBase := tmpFile.Name()
+	cmd := exec.Command("tesseract", tmpFile.Name(), outBase)
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	defer os.Remove(outBase + ".txt")
+
+	data, err := ioutil.ReadFile(outBase + ".txt")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,150 @@
+// Package watch recursively watches the document archive for PDF create,
+// write, rename and delete events, coalescing bursts (e.g. a temp-then-
+// rename write) into debounced add/remove/change deltas.
+package watch
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Delta is a batch of archive changes observed since the last flush.
+type Delta struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+func (d Delta) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Watcher watches root recursively for *.pdf changes and emits a Delta on
+// Deltas() no more often than once per debounce window.
+type Watcher struct {
+	fsw      *fsnotify.Watcher
+	root     string
+	debounce time.Duration
+	deltas   chan Delta
+
+	mu      sync.Mutex
+	pending map[string]fsnotify.Op
+	timer   *time.Timer
+}
+
+// New starts watching root and returns a Watcher whose Deltas channel
+// receives debounced change batches until Close is called.
+func New(root string, debounce time.Duration) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		fsw:      fsw,
+		root:     root,
+		debounce: debounce,
+		deltas:   make(chan Delta),
+		pending:  make(map[string]fsnotify.Op),
+	}
+
+	if err := w.addRecursive(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+func (w *Watcher) addRecursive(root string) error {
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return w.fsw.Add(p)
+		}
+		return nil
+	})
+}
+
+// Deltas returns the channel debounced change batches are delivered on.
+func (w *Watcher) Deltas() <-chan Delta {
+	return w.deltas
+}
+
+// Close stops watching and releases the underlying fsnotify handles.
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+func (w *Watcher) run() {
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+					w.fsw.Add(event.Name)
+					continue
+				}
+			}
+			if !strings.EqualFold(filepath.Ext(event.Name), ".pdf") {
+				continue
+			}
+			w.schedule(event)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("watch: error: %v", err.Error())
+		}
+	}
+}
+
+func (w *Watcher) schedule(event fsnotify.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending[event.Name] |= event.Op
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.debounce, w.flush)
+}
+
+func (w *Watcher) flush() {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = make(map[string]fsnotify.Op)
+	w.mu.Unlock()
+
+	var delta Delta
+	for name, op := range pending {
+		switch {
+		case op&fsnotify.Remove == fsnotify.Remove || op&fsnotify.Rename == fsnotify.Rename:
+			if _, err := os.Stat(name); err != nil {
+				delta.Removed = append(delta.Removed, name)
+			} else {
+				delta.Changed = append(delta.Changed, name)
+			}
+		case op&fsnotify.Create == fsnotify.Create:
+			delta.Added = append(delta.Added, name)
+		case op&fsnotify.Write == fsnotify.Write:
+			delta.Changed = append(delta.Changed, name)
+		}
+	}
+
+	if !delta.empty() {
+		w.deltas <- delta
+	}
+}